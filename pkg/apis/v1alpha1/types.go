@@ -0,0 +1,216 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ChartMgrResourceKind is the kind name of the ChartManager custom
+	// resource.
+	ChartMgrResourceKind = "ChartManager"
+
+	// ChartMgrResourcePlural is the plural resource name used when
+	// registering the ChartManager custom resource with the API server.
+	ChartMgrResourcePlural = "chartmanagers"
+
+	// ChartMgrGroupName is the API group the ChartManager custom
+	// resource belongs to.
+	ChartMgrGroupName = "logicmonitor.com"
+
+	// ChartMgrVersion is the API version served for the ChartManager
+	// custom resource.
+	ChartMgrVersion = "v1alpha1"
+)
+
+// SchemeGroupVersion is the group version used to register these objects.
+var SchemeGroupVersion = schema.GroupVersion{Group: ChartMgrGroupName, Version: ChartMgrVersion}
+
+// ChartMgrState represents the state of a ChartManager's managed release.
+type ChartMgrState string
+
+const (
+	// StateUnknown is the zero value for a ChartManager that has not yet
+	// been reconciled.
+	StateUnknown ChartMgrState = ""
+
+	// StateDeployed indicates the managed release was deployed
+	// successfully.
+	StateDeployed ChartMgrState = "Deployed"
+
+	// StateFailed indicates the managed release failed to deploy.
+	StateFailed ChartMgrState = "Failed"
+
+	// StateConflicted indicates another ChartManager that ranks ahead
+	// of this one (a numerically lower Tier, or an equal Tier and a name
+	// that sorts first) targets the same release, and this one is
+	// standing down until that conflict clears.
+	StateConflicted ChartMgrState = "Conflicted"
+
+	// StateRolledBack indicates spec.atomic was set and an install or
+	// upgrade failed to deploy, so the controller automatically
+	// uninstalled (first install) or rolled back (upgrade) the release.
+	// status.message preserves the original failure.
+	StateRolledBack ChartMgrState = "RolledBack"
+)
+
+// DefaultTier is the Tier applied to a ChartManager that does not set
+// spec.tier.
+const DefaultTier = 100
+
+// ChartManager is a specification for a ChartManager resource.
+type ChartManager struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChartManagerSpec `json:"spec"`
+	Status ChartMgrStatus   `json:"status,omitempty"`
+}
+
+// ChartManagerSpec is the spec for a ChartManager resource.
+type ChartManagerSpec struct {
+	// Chart identifies the Helm chart to deploy.
+	Chart ChartSpec `json:"chart"`
+
+	// ReleaseName is the name of the Helm release to create or update.
+	ReleaseName string `json:"releaseName"`
+
+	// Namespace is the namespace the release is installed into.
+	Namespace string `json:"namespace"`
+
+	// Values holds the raw YAML values passed to the chart at
+	// install/upgrade time.
+	Values string `json:"values,omitempty"`
+
+	// CreateOnly prevents the controller from upgrading an existing
+	// release in response to spec updates.
+	CreateOnly bool `json:"createOnly,omitempty"`
+
+	// TimeoutSeconds bounds how long the controller waits for the
+	// release to reach the Deployed state before giving up. Zero means
+	// fall back to the controller's configured default.
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+
+	// Tier resolves conflicts between ChartManagers that target the
+	// same release: when two or more target the same (namespace,
+	// releaseName), the one with the numerically lowest Tier is
+	// deployed and the rest are marked Conflicted. Equal Tiers are
+	// ranked by ChartManager name. Zero means DefaultTier.
+	Tier int `json:"tier,omitempty"`
+
+	// Atomic causes a failed install to be uninstalled, and a failed
+	// upgrade to be rolled back to its previous revision, instead of
+	// being left in a failed state. The resulting ChartManager status
+	// is StateRolledBack, with the original error preserved in
+	// status.message.
+	Atomic bool `json:"atomic,omitempty"`
+
+	// MaxHistory caps the number of revisions kept for the release on
+	// upgrade. Zero means fall back to Helm's own default.
+	MaxHistory int `json:"maxHistory,omitempty"`
+
+	// CleanupOnFail causes an upgrade to delete any resources it newly
+	// created if the upgrade fails.
+	CleanupOnFail bool `json:"cleanupOnFail,omitempty"`
+
+	// DisableHooks skips running the chart's hooks on install or
+	// upgrade.
+	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// ResetValues ignores the previously deployed release's values and
+	// uses only the chart's defaults merged with spec.values on
+	// upgrade.
+	ResetValues bool `json:"resetValues,omitempty"`
+
+	// ReuseValues reuses the previously deployed release's values,
+	// merging in spec.values, on upgrade.
+	ReuseValues bool `json:"reuseValues,omitempty"`
+
+	// PostRenderer, if set, is run against the chart's rendered
+	// manifest before it is applied.
+	PostRenderer *PostRendererSpec `json:"postRenderer,omitempty"`
+}
+
+// PostRendererSpec configures a post-renderer executable that is run
+// against the chart's rendered manifest stream before it is applied,
+// implementing Helm's postrender.PostRenderer interface via exec.
+type PostRendererSpec struct {
+	// Command is the path to the post-renderer executable.
+	Command string `json:"command"`
+
+	// Args are passed to Command.
+	Args []string `json:"args,omitempty"`
+}
+
+// EffectiveTier returns spec.Tier, or DefaultTier if it is unset.
+func (s ChartManagerSpec) EffectiveTier() int {
+	if s.Tier == 0 {
+		return DefaultTier
+	}
+	return s.Tier
+}
+
+// ChartSpec identifies a Helm chart.
+type ChartSpec struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// ChartMgrStatus is the status for a ChartManager resource.
+type ChartMgrStatus struct {
+	State       ChartMgrState `json:"state"`
+	ReleaseName string        `json:"releaseName"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// ChartManagerList is a list of ChartManager resources.
+type ChartManagerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ChartManager `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *ChartManager) DeepCopyObject() runtime.Object {
+	return c.DeepCopy()
+}
+
+// DeepCopy returns a deep copy of the ChartManager.
+func (c *ChartManager) DeepCopy() *ChartManager {
+	if c == nil {
+		return nil
+	}
+	out := new(ChartManager)
+	*out = *c
+	out.ObjectMeta = *c.ObjectMeta.DeepCopy()
+	out.Spec = c.Spec
+	if c.Spec.PostRenderer != nil {
+		postRenderer := *c.Spec.PostRenderer
+		if c.Spec.PostRenderer.Args != nil {
+			postRenderer.Args = append([]string(nil), c.Spec.PostRenderer.Args...)
+		}
+		out.Spec.PostRenderer = &postRenderer
+	}
+	out.Status = c.Status
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (c *ChartManagerList) DeepCopyObject() runtime.Object {
+	if c == nil {
+		return nil
+	}
+	out := new(ChartManagerList)
+	*out = *c
+	out.ListMeta = c.ListMeta
+	if c.Items != nil {
+		out.Items = make([]ChartManager, len(c.Items))
+		for i := range c.Items {
+			out.Items[i] = *c.Items[i].DeepCopy()
+		}
+	}
+	return out
+}
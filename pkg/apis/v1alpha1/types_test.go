@@ -0,0 +1,24 @@
+package v1alpha1
+
+import "testing"
+
+func TestChartManagerSpecEffectiveTier(t *testing.T) {
+	tests := []struct {
+		name string
+		tier int
+		want int
+	}{
+		{name: "unset falls back to default", tier: 0, want: DefaultTier},
+		{name: "explicit tier is returned as-is", tier: 5, want: 5},
+		{name: "negative tier is returned as-is", tier: -1, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := ChartManagerSpec{Tier: tt.tier}
+			if got := spec.EffectiveTier(); got != tt.want {
+				t.Errorf("EffectiveTier() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
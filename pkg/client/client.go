@@ -0,0 +1,48 @@
+package client
+
+import (
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+)
+
+// Client is a thin wrapper around a REST client scoped to the
+// ChartManager custom resource.
+type Client struct {
+	RESTClient rest.Interface
+	Logger     log.Logger
+}
+
+// NewForConfig builds a Client and the runtime.Scheme used to
+// (de)serialize ChartManager objects from the supplied rest.Config.
+func NewForConfig(cfg *rest.Config, logger log.Logger) (*Client, *runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := addKnownTypes(scheme); err != nil {
+		return nil, nil, err
+	}
+
+	config := *cfg
+	config.GroupVersion = &crv1alpha1.SchemeGroupVersion
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.NewCodecFactory(scheme).WithoutConversion()
+
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Client{RESTClient: restClient, Logger: logger}, scheme, nil
+}
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	metav1.AddToGroupVersion(scheme, crv1alpha1.SchemeGroupVersion)
+	scheme.AddKnownTypes(crv1alpha1.SchemeGroupVersion,
+		&crv1alpha1.ChartManager{},
+		&crv1alpha1.ChartManagerList{},
+	)
+	return nil
+}
@@ -0,0 +1,18 @@
+package config
+
+// Config holds the runtime configuration for the Chart Manager
+// controller, typically populated from command line flags.
+type Config struct {
+	// Kubeconfig is the path to a kubeconfig file. Empty when running
+	// in cluster.
+	Kubeconfig string
+
+	// StorageDriver selects where Helm v3 stores release information:
+	// "secret" (the default), "configmap", or "memory".
+	StorageDriver string
+
+	// DefaultTimeoutSeconds is how long the controller waits for a
+	// release to reach the Deployed state when a ChartManager does not
+	// set spec.timeoutSeconds.
+	DefaultTimeoutSeconds int64
+}
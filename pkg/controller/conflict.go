@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// releaseIndex is the name of the indexer index keyed on the release a
+// ChartManager targets, used to find conflicting ChartManagers.
+const releaseIndex = "byRelease"
+
+// releaseIndexFunc indexes a ChartManager by the (namespace, release
+// name) pair it deploys to. ChartManagers sharing that pair conflict
+// with one another.
+func releaseIndexFunc(obj interface{}) ([]string, error) {
+	chartmgr, ok := obj.(*crv1alpha1.ChartManager)
+	if !ok {
+		return nil, fmt.Errorf("object %T is not a ChartManager", obj)
+	}
+	return []string{releaseKey(chartmgr)}, nil
+}
+
+// releaseKey identifies the release a ChartManager deploys to. This is
+// the minimum conflict detection required by the spec; a deeper mode
+// that renders the chart and indexes owned GVK/name/namespace tuples is
+// not yet implemented.
+func releaseKey(chartmgr *crv1alpha1.ChartManager) string {
+	return chartmgr.Spec.Namespace + "/" + chartmgr.Spec.ReleaseName
+}
+
+// conflictGroup returns every ChartManager, including chartmgr itself,
+// that targets the same release as chartmgr, ordered by Tier (lowest,
+// i.e. highest priority, first) and then by name for determinism.
+func (c *Controller) conflictGroup(chartmgr *crv1alpha1.ChartManager) ([]*crv1alpha1.ChartManager, error) {
+	objs, err := c.indexer.ByIndex(releaseIndex, releaseKey(chartmgr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up ChartManagers targeting release %s: %v", releaseKey(chartmgr), err)
+	}
+
+	group := make([]*crv1alpha1.ChartManager, 0, len(objs))
+	for _, obj := range objs {
+		group = append(group, obj.(*crv1alpha1.ChartManager))
+	}
+
+	sort.Slice(group, func(i, j int) bool {
+		if group[i].Spec.EffectiveTier() != group[j].Spec.EffectiveTier() {
+			return group[i].Spec.EffectiveTier() < group[j].Spec.EffectiveTier()
+		}
+		return group[i].Name < group[j].Name
+	})
+	return group, nil
+}
+
+// enqueueConflictGroup enqueues every ChartManager that currently
+// targets the same release as chartmgr, so that a tier change or a
+// deletion is immediately re-evaluated across the whole group.
+func (c *Controller) enqueueConflictGroup(chartmgr *crv1alpha1.ChartManager) {
+	group, err := c.conflictGroup(chartmgr)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, member := range group {
+		c.enqueue(member)
+	}
+}
+
+// sameChartMgr reports whether a and b are the same ChartManager
+// resource (as opposed to merely targeting the same release).
+func sameChartMgr(a, b *crv1alpha1.ChartManager) bool {
+	return a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// markConflicted records that chartmgr has lost a tier conflict to
+// winner, without touching the Helm release it would otherwise manage.
+func (c *Controller) markConflicted(chartmgr, winner *crv1alpha1.ChartManager) error {
+	chartmgrCopy := chartmgr.DeepCopy()
+	chartmgrCopy.Status = crv1alpha1.ChartMgrStatus{
+		State:       crv1alpha1.StateConflicted,
+		ReleaseName: chartmgr.Spec.ReleaseName,
+		Message: fmt.Sprintf("release %s is owned by ChartManager %s/%s (tier %d)",
+			releaseKey(chartmgr), winner.Namespace, winner.Name, winner.Spec.EffectiveTier()),
+	}
+	return c.put(chartmgrCopy)
+}
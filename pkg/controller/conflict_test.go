@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"testing"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestController(chartmgrs ...*crv1alpha1.ChartManager) *Controller {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		releaseIndex: releaseIndexFunc,
+	})
+	for _, chartmgr := range chartmgrs {
+		indexer.Add(chartmgr)
+	}
+	return &Controller{indexer: indexer}
+}
+
+func newTestChartMgr(name string, tier int) *crv1alpha1.ChartManager {
+	chartmgr := &crv1alpha1.ChartManager{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+	chartmgr.Spec.Namespace = "default"
+	chartmgr.Spec.ReleaseName = "shared-release"
+	chartmgr.Spec.Tier = tier
+	return chartmgr
+}
+
+func TestConflictGroupOrdering(t *testing.T) {
+	low := newTestChartMgr("low-tier", 1)
+	high := newTestChartMgr("high-tier", 10)
+	unset := newTestChartMgr("unset-tier", 0)
+	tieA := newTestChartMgr("tie-a", 5)
+	tieB := newTestChartMgr("tie-b", 5)
+
+	c := newTestController(high, unset, tieB, low, tieA)
+
+	group, err := c.conflictGroup(low)
+	if err != nil {
+		t.Fatalf("conflictGroup() error = %v", err)
+	}
+
+	wantOrder := []string{"low-tier", "tie-a", "tie-b", "high-tier", "unset-tier"}
+	if len(group) != len(wantOrder) {
+		t.Fatalf("conflictGroup() returned %d members, want %d", len(group), len(wantOrder))
+	}
+	for i, name := range wantOrder {
+		if group[i].Name != name {
+			t.Errorf("group[%d].Name = %s, want %s", i, group[i].Name, name)
+		}
+	}
+}
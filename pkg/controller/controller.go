@@ -3,18 +3,50 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
 	chartmgrclient "github.com/logicmonitor/k8s-chart-manager-controller/pkg/client"
 	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/config"
 	lmhelm "github.com/logicmonitor/k8s-chart-manager-controller/pkg/lmhelm"
-	log "github.com/sirupsen/logrus"
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/log"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// numWorkers is the number of reconcile workers processing the
+	// workqueue concurrently.
+	numWorkers = 2
+
+	// leaseName is the name of the Lease object used for leader
+	// election among controller replicas.
+	leaseName = "chart-manager-controller"
+
+	// leaseNamespace is the namespace the leader election Lease lives
+	// in.
+	leaseNamespace = "kube-system"
+
+	// defaultTimeout is the fallback deploy timeout used when neither
+	// the ChartManager nor the controller config specify one.
+	defaultTimeout = 2 * time.Minute
+
+	// pollInterval is how often waitForReleaseToDeploy checks release
+	// status while waiting for it to become Deployed.
+	pollInterval = 30 * time.Second
 )
 
 // Controller is the Kubernetes controller object for LogicMonitor
@@ -24,55 +56,118 @@ type Controller struct {
 	ChartMgrScheme *runtime.Scheme
 	Config         *config.Config
 	HelmClient     *lmhelm.Client
+	Logger         log.Logger
+
+	kubeClient kubernetes.Interface
+	indexer    cache.Indexer
+	informer   cache.Controller
+	queue      workqueue.RateLimitingInterface
+}
+
+// Option configures optional Controller behavior at construction time.
+type Option func(*Controller)
+
+// WithLogger overrides the Controller's default logrus-backed Logger,
+// e.g. with log.NewKlog() or log.NewZap(zapLogger).
+func WithLogger(logger log.Logger) Option {
+	return func(c *Controller) { c.Logger = logger }
 }
 
 // New instantiates and returns a Controller and an error if any.
-func New(chartmgrconfig *config.Config) (*Controller, error) {
+func New(chartmgrconfig *config.Config, opts ...Option) (*Controller, error) {
+	c := &Controller{
+		Config: chartmgrconfig,
+		Logger: log.NewLogrus(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	// Instantiate the Kubernetes in cluster config.
 	restconfig, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	kubeClient, err := kubernetes.NewForConfig(restconfig)
+	if err != nil {
+		return nil, err
+	}
+	c.kubeClient = kubeClient
+
 	// Instantiate the ChartMgr client.
-	client, chartmgrscheme, err := chartmgrclient.NewForConfig(restconfig)
+	client, chartmgrscheme, err := chartmgrclient.NewForConfig(restconfig, c.Logger)
 	if err != nil {
 		return nil, err
 	}
+	c.Client = client
+	c.ChartMgrScheme = chartmgrscheme
 
 	// initialize our LM helm wrapper struct
 	helmClient := &lmhelm.Client{}
-	err = helmClient.Init(chartmgrconfig, restconfig)
+	err = helmClient.Init(chartmgrconfig, restconfig, c.Logger)
 	if err != nil {
 		return nil, err
 	}
+	c.HelmClient = helmClient
 
-	// start a controller on instances of our custom resource
-	c := &Controller{
-		Client:         client,
-		ChartMgrScheme: chartmgrscheme,
-		Config:         chartmgrconfig,
-		HelmClient:     helmClient,
-	}
+	c.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	return c, nil
 }
 
-// Run starts a Chart Manager resource controller.
+// Run starts a Chart Manager resource controller. It blocks, running
+// the controller while it holds the leader election lease, until ctx is
+// cancelled or the lease is lost.
 func (c *Controller) Run(ctx context.Context) error {
-	// Manage Chart Manager objects
-	err := c.manage(ctx)
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	id, err := os.Hostname()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to determine leader election identity: %v", err)
 	}
 
-	log.Info("Successfully started Chart Manager controller")
-	<-ctx.Done()
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		leaseNamespace,
+		leaseName,
+		c.kubeClient.CoreV1(),
+		c.kubeClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: id},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build leader election lock: %v", err)
+	}
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				c.Logger.Infof("%s started leading", id)
+				c.runWorkers(ctx)
+			},
+			OnStoppedLeading: func() {
+				c.Logger.Infof("%s stopped leading", id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					c.Logger.Infof("%s is leading", identity)
+				}
+			},
+		},
+	})
 
 	return ctx.Err()
 }
 
-func (c *Controller) manage(ctx context.Context) error {
-	_, controller := cache.NewInformer(
+// runWorkers starts the informer and a fixed pool of reconcile workers,
+// and blocks until ctx is cancelled and the workers have drained.
+func (c *Controller) runWorkers(ctx context.Context) {
+	c.indexer, c.informer = cache.NewIndexerInformer(
 		cache.NewListWatchFromClient(
 			c.RESTClient,
 			crv1alpha1.ChartMgrResourcePlural,
@@ -82,87 +177,227 @@ func (c *Controller) manage(ctx context.Context) error {
 		&crv1alpha1.ChartManager{},
 		0,
 		cache.ResourceEventHandlerFuncs{
-			AddFunc:    c.addFunc,
-			UpdateFunc: c.updateFunc,
-			DeleteFunc: c.deleteFunc,
+			AddFunc:    c.handleAddOrUpdate,
+			UpdateFunc: func(oldObj, newObj interface{}) { c.handleAddOrUpdate(newObj) },
+			DeleteFunc: c.handleDelete,
+		},
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			releaseIndex:         releaseIndexFunc,
 		},
 	)
 
-	go controller.Run(ctx.Done())
-	return nil
+	go c.informer.Run(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		utilruntime.HandleError(errors.New("timed out waiting for ChartManager informer cache to sync"))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() { c.runWorker(ctx) }, time.Second, ctx.Done())
+		}()
+	}
+
+	c.Logger.Infof("Successfully started Chart Manager controller")
+	<-ctx.Done()
+	c.queue.ShutDown()
+	wg.Wait()
 }
 
-func (c *Controller) addFunc(obj interface{}) {
-	go func(obj interface{}) {
-		chartmgr := obj.(*crv1alpha1.ChartManager)
-		rls, err := CreateOrUpdateChartMgr(chartmgr, c.HelmClient)
-		if err != nil {
-			log.Errorf("%s", err)
-			c.updateChartMgrStatus(chartmgr, rls, err.Error())
-			return
-		}
+// enqueue adds the namespace/name key of obj to the workqueue. It
+// handles the cache.DeletedFinalStateUnknown wrapper used when a delete
+// is observed after a watch disconnect.
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
 
-		err = c.updateStatus(chartmgr, rls)
-		if err != nil {
-			return
-		}
-		log.Infof("Chart Manager %s status is %s", chartmgr.Name, rls.Status())
-		log.Infof("Created Chart Manager: %s", chartmgr.Name)
-	}(obj)
+// handleAddOrUpdate enqueues the ChartManager itself along with every
+// other ChartManager that targets the same release, so that a tier
+// change is re-evaluated across the whole conflict group.
+func (c *Controller) handleAddOrUpdate(obj interface{}) {
+	c.enqueue(obj)
+	if chartmgr := asChartManager(obj); chartmgr != nil {
+		c.enqueueConflictGroup(chartmgr)
+	}
 }
 
-func (c *Controller) updateFunc(oldObj, newObj interface{}) {
-	go func(oldObj interface{}, newObj interface{}) {
-		_ = oldObj.(*crv1alpha1.ChartManager)
-		newChartMgr := newObj.(*crv1alpha1.ChartManager)
+// handleDelete enqueues the deleted ChartManager for cleanup, along with
+// the rest of its conflict group so that removing a winning ChartManager
+// promotes the next one in tier order.
+func (c *Controller) handleDelete(obj interface{}) {
+	c.enqueue(obj)
+	if chartmgr := asChartManager(obj); chartmgr != nil {
+		c.enqueueConflictGroup(chartmgr)
+	}
+}
 
-		rls, err := CreateOrUpdateChartMgr(newChartMgr, c.HelmClient)
-		if err != nil {
-			log.Errorf("%s", err)
-			c.updateChartMgrStatus(newChartMgr, rls, err.Error())
-			return
-		}
+// asChartManager unwraps obj, including the cache.DeletedFinalStateUnknown
+// tombstone used for deletes observed after a watch disconnect, into a
+// *crv1alpha1.ChartManager. It returns nil if obj is neither.
+func asChartManager(obj interface{}) *crv1alpha1.ChartManager {
+	switch v := obj.(type) {
+	case *crv1alpha1.ChartManager:
+		return v
+	case cache.DeletedFinalStateUnknown:
+		return asChartManager(v.Obj)
+	default:
+		return nil
+	}
+}
+
+// runWorker processes items from the workqueue until it is shut down.
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
 
-		if lmhelm.CreateOnly(newChartMgr) {
-			log.Infof("CreateOnly mode. Ignoring update of chart manager %s.", newChartMgr.Name)
-			return
+// processNextWorkItem pops a single key off the workqueue and reconciles
+// it, requeueing with exponential backoff on transient errors.
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	err := c.reconcile(ctx, key.(string))
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+
+	c.Logger.Errorf("Error reconciling %s, requeuing: %v", key, err)
+	c.queue.AddRateLimited(key)
+	return true
+}
+
+// reconcile brings the ChartManager identified by key in line with its
+// desired state: installing or upgrading its release, or deleting it if
+// the ChartManager itself has been deleted. Every log line emitted for
+// this reconcile carries a reconcileID so operators can follow a single
+// reconcile across concurrent workers.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	logger := c.Logger.With("chartmgr", key, "reconcileID", uuid.NewUUID())
+
+	obj, exists, err := c.indexer.GetByKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to fetch ChartManager %s from cache: %v", key, err)
+	}
+
+	if !exists {
+		return c.reconcileDelete(ctx, logger, key)
+	}
+
+	return c.reconcileCreateOrUpdate(ctx, logger, obj.(*crv1alpha1.ChartManager))
+}
+
+func (c *Controller) reconcileCreateOrUpdate(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager) error {
+	logger = logger.With("release", chartmgr.Spec.ReleaseName)
+
+	group, err := c.conflictGroup(chartmgr)
+	if err != nil {
+		return err
+	}
+	if winner := group[0]; !sameChartMgr(winner, chartmgr) {
+		if err := c.markConflicted(chartmgr, winner); err != nil {
+			logger.Errorf("Failed to update status: %v", err)
+			return err
 		}
+		logger.Infof("Chart Manager %s is Conflicted: release %s is owned by %s/%s",
+			chartmgr.Name, releaseKey(chartmgr), winner.Namespace, winner.Name)
+		return nil
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, c.timeoutFor(chartmgr))
+	defer cancel()
 
-		err = c.updateStatus(newChartMgr, rls)
-		if err != nil {
-			return
+	rls, created, err := CreateOrUpdateChartMgr(opCtx, logger, chartmgr, c.HelmClient)
+	if err != nil {
+		logger.Errorf("%s", err)
+		var rolledBack *lmhelm.RolledBackError
+		if errors.As(err, &rolledBack) {
+			c.markRolledBack(logger, chartmgr, rolledBack.Err)
+		} else {
+			c.updateChartMgrStatus(logger, chartmgr, rls, err.Error())
 		}
-		log.Infof("Updated Chart Manager: %s", newChartMgr.Name)
-	}(oldObj, newObj)
+		return err
+	}
+
+	// CreateOnly only ever skips subsequent updates to an
+	// already-deployed release; the reconcile that creates it must
+	// still write status.
+	if !created && lmhelm.CreateOnly(chartmgr) && rls.Deployed() {
+		logger.Infof("CreateOnly mode. Ignoring update of chart manager %s.", chartmgr.Name)
+		return nil
+	}
+
+	if err := c.updateStatus(opCtx, logger, chartmgr, rls); err != nil {
+		return err
+	}
+
+	logger.Infof("Chart Manager %s status is %s", chartmgr.Name, rls.Status())
+	logger.Infof("Reconciled Chart Manager: %s", chartmgr.Name)
+	return nil
 }
 
-func (c *Controller) deleteFunc(obj interface{}) {
-	go func(obj interface{}) {
-		chartmgr := obj.(*crv1alpha1.ChartManager)
+func (c *Controller) reconcileDelete(ctx context.Context, logger log.Logger, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid ChartManager key %s: %v", key, err)
+	}
 
-		_, err := DeleteChartMgr(chartmgr, c.HelmClient)
-		if err != nil {
-			log.Errorf("Failed to delete Chart Manager: %v", err)
-			return
-		}
-		log.Infof("Deleted Chart Manager: %s", chartmgr.Name)
-	}(obj)
+	chartmgr := &crv1alpha1.ChartManager{}
+	chartmgr.Namespace = namespace
+	chartmgr.Name = name
+
+	opCtx, cancel := context.WithTimeout(ctx, c.timeoutFor(chartmgr))
+	defer cancel()
+
+	_, err = DeleteChartMgr(opCtx, logger, chartmgr, c.HelmClient)
+	if err != nil {
+		return fmt.Errorf("failed to delete Chart Manager %s: %v", key, err)
+	}
+	logger.Infof("Deleted Chart Manager: %s", key)
+	return nil
 }
 
-func (c *Controller) updateStatus(chartmgr *crv1alpha1.ChartManager, rls *lmhelm.Release) error {
-	err := c.waitForReleaseToDeploy(rls)
+// timeoutFor returns the deploy timeout for chartmgr: its own
+// spec.timeoutSeconds if set, otherwise the controller's configured
+// default, falling back to defaultTimeout if neither is set.
+func (c *Controller) timeoutFor(chartmgr *crv1alpha1.ChartManager) time.Duration {
+	if chartmgr.Spec.TimeoutSeconds > 0 {
+		return time.Duration(chartmgr.Spec.TimeoutSeconds) * time.Second
+	}
+	if c.Config.DefaultTimeoutSeconds > 0 {
+		return time.Duration(c.Config.DefaultTimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+func (c *Controller) updateStatus(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager, rls *lmhelm.Release) error {
+	err := c.waitForReleaseToDeploy(ctx, logger, rls)
 	if err != nil {
-		log.Errorf("Failed to verify that release %v deployed: %v", rls.Name(), err)
-		c.updateChartMgrStatus(chartmgr, rls, err.Error())
+		logger.Errorf("Failed to verify that release %v deployed: %v", rls.Name(), err)
+		c.updateChartMgrStatus(logger, chartmgr, rls, err.Error())
 	} else {
-		log.Infof("Chart Manager %s release %s status is Deployed", chartmgr.Name, rls.Name())
-		c.updateChartMgrStatus(chartmgr, rls, string(rls.Status()))
+		logger.Infof("Chart Manager %s release %s status is Deployed", chartmgr.Name, rls.Name())
+		c.updateChartMgrStatus(logger, chartmgr, rls, string(rls.Status()))
 	}
 	return err
 }
 
-func (c *Controller) updateChartMgrStatus(chartmgr *crv1alpha1.ChartManager, rls *lmhelm.Release, message string) {
-	log.Debugf("Updating Chart Manager status: state=%s release=%s", rls.Status(), rls.Name())
+func (c *Controller) updateChartMgrStatus(logger log.Logger, chartmgr *crv1alpha1.ChartManager, rls *lmhelm.Release, message string) {
+	logger.Debugf("Updating Chart Manager status: state=%s release=%s", rls.Status(), rls.Name())
 	chartmgrCopy := chartmgr.DeepCopy()
 	chartmgrCopy.Status = crv1alpha1.ChartMgrStatus{
 		State:       rls.Status(),
@@ -172,7 +407,23 @@ func (c *Controller) updateChartMgrStatus(chartmgr *crv1alpha1.ChartManager, rls
 
 	err := c.put(chartmgrCopy)
 	if err != nil {
-		log.Errorf("Failed to update status: %v", err)
+		logger.Errorf("Failed to update status: %v", err)
+	}
+}
+
+// markRolledBack records that chartmgr's release was automatically
+// uninstalled or rolled back after a failed atomic install/upgrade,
+// preserving the original failure in status.message.
+func (c *Controller) markRolledBack(logger log.Logger, chartmgr *crv1alpha1.ChartManager, cause error) {
+	chartmgrCopy := chartmgr.DeepCopy()
+	chartmgrCopy.Status = crv1alpha1.ChartMgrStatus{
+		State:       crv1alpha1.StateRolledBack,
+		ReleaseName: chartmgr.Spec.ReleaseName,
+		Message:     cause.Error(),
+	}
+
+	if err := c.put(chartmgrCopy); err != nil {
+		logger.Errorf("Failed to update status: %v", err)
 	}
 }
 
@@ -186,19 +437,26 @@ func (c *Controller) put(chartmgr *crv1alpha1.ChartManager) error {
 		Error()
 }
 
-func (c *Controller) waitForReleaseToDeploy(rls *lmhelm.Release) error {
-	timeout := time.After(2 * time.Minute)
-	ticker := time.NewTicker(30 * time.Second)
+// waitForReleaseToDeploy checks rls every pollInterval until it reports
+// Deployed or ctx is done, e.g. because its deadline (set by
+// timeoutFor) expired or the reconcile was cancelled. rls is the release
+// as returned by the install or upgrade and is not re-read from Helm
+// between checks, so a release that was not Deployed on return waits
+// until ctx is done.
+func (c *Controller) waitForReleaseToDeploy(ctx context.Context, logger log.Logger, rls *lmhelm.Release) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		logger.Debugf("Checking status of release %s", rls.Name())
+		if rls.Deployed() {
+			return nil
+		}
 
-	for c := ticker.C; ; <-c {
 		select {
-		case <-timeout:
-			return errors.New("Timed out waiting for release to deploy")
-		default:
-			log.Debugf("Checking status of release %s", rls.Name())
-			if rls.Deployed() {
-				return nil
-			}
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for release to deploy: %v", ctx.Err())
+		case <-ticker.C:
 		}
 	}
 }
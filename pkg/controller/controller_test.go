@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/config"
+)
+
+func TestControllerTimeoutFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		specSeconds    int64
+		defaultSeconds int64
+		want           time.Duration
+	}{
+		{name: "falls back to defaultTimeout when nothing is set", want: defaultTimeout},
+		{name: "uses controller default when spec is unset", defaultSeconds: 90, want: 90 * time.Second},
+		{name: "spec overrides the controller default", specSeconds: 30, defaultSeconds: 90, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{Config: &config.Config{DefaultTimeoutSeconds: tt.defaultSeconds}}
+			chartmgr := &crv1alpha1.ChartManager{}
+			chartmgr.Spec.TimeoutSeconds = tt.specSeconds
+
+			if got := c.timeoutFor(chartmgr); got != tt.want {
+				t.Errorf("timeoutFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
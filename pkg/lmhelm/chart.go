@@ -0,0 +1,126 @@
+package lmhelm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	// localChartScheme is used for charts already mounted into the
+	// controller's pod, e.g. via a ConfigMap or init container.
+	localChartScheme = "chart://"
+
+	// ociChartScheme is used for charts hosted in an OCI registry.
+	ociChartScheme = "oci://"
+)
+
+// locateChart resolves the chart referenced by a ChartManager to a
+// loaded chart.Chart. Charts may be a local path mounted into the pod
+// (chart:///path), an OCI reference (oci://host/repo:tag), or a chart in
+// a classic Helm repository named by spec.chart.repository.
+func locateChart(chartmgr *crv1alpha1.ChartManager) (*chart.Chart, error) {
+	name := chartmgr.Spec.Chart.Name
+	if name == "" {
+		return nil, fmt.Errorf("chartmgr %s has no chart name set", chartmgr.Name)
+	}
+
+	path, cleanup, err := resolveChartPath(chartmgr)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chrt, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %s: %v", path, err)
+	}
+	return chrt, nil
+}
+
+// resolveChartPath returns the local filesystem path of the chart
+// archive or directory to load, downloading it first if it is not
+// already local. The returned cleanup func, if non-nil, removes the
+// temporary download directory and must be called once the chart has
+// been loaded.
+func resolveChartPath(chartmgr *crv1alpha1.ChartManager) (string, func(), error) {
+	name := chartmgr.Spec.Chart.Name
+
+	switch {
+	case strings.HasPrefix(name, localChartScheme):
+		return filepath.Clean(strings.TrimPrefix(name, localChartScheme)), nil, nil
+	case strings.HasPrefix(name, ociChartScheme):
+		return downloadChart(chartmgr, name)
+	case chartmgr.Spec.Chart.Repository != "":
+		return downloadChart(chartmgr, name)
+	default:
+		// Assume the chart is already available on disk, e.g. baked
+		// into the controller image.
+		return name, nil, nil
+	}
+}
+
+// downloadChart fetches a chart from its repository (classic or OCI)
+// using Helm's own downloader, so repository auth, TLS and index
+// caching behave exactly as they would for the helm CLI. The returned
+// cleanup func removes the temporary directory the chart was downloaded
+// into; callers must call it once they are done with the returned path.
+func downloadChart(chartmgr *crv1alpha1.ChartManager, ref string) (string, func(), error) {
+	settings := cli.New()
+
+	destDir, err := os.MkdirTemp("", "chartmgr-"+chartmgr.Spec.ReleaseName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create chart download directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(destDir) }
+
+	registryClient, err := registry.NewClient(registry.ClientOptWriter(os.Stderr))
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to create OCI registry client: %v", err)
+	}
+
+	dl := downloader.ChartDownloader{
+		Out:              os.Stderr,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+
+	if chartmgr.Spec.Chart.Repository != "" && !strings.HasPrefix(ref, ociChartScheme) {
+		entry := &repo.Entry{
+			Name: chartmgr.Spec.ReleaseName,
+			URL:  chartmgr.Spec.Chart.Repository,
+		}
+		chartRepo, err := repo.NewChartRepository(entry, dl.Getters)
+		if err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("failed to configure repository %s: %v", entry.URL, err)
+		}
+		if _, err := chartRepo.DownloadIndexFile(); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("failed to download repository index for %s: %v", entry.URL, err)
+		}
+		ref = fmt.Sprintf("%s/%s", entry.Name, ref)
+	}
+
+	archive, _, err := dl.DownloadTo(ref, chartmgr.Spec.Chart.Version, destDir)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to download chart %s: %v", ref, err)
+	}
+	return archive, cleanup, nil
+}
@@ -0,0 +1,201 @@
+package lmhelm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/log"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// CreateOnly reports whether the ChartManager should never be upgraded
+// once its release has been created.
+func CreateOnly(chartmgr *crv1alpha1.ChartManager) bool {
+	return chartmgr.Spec.CreateOnly
+}
+
+// RolledBackError wraps the error from a failed install or upgrade whose
+// ChartManager had spec.atomic set, after Helm has already uninstalled
+// (install) or rolled back (upgrade) the release in response. Callers
+// should report the wrapped error while recording the ChartManager as
+// RolledBack rather than Failed.
+type RolledBackError struct {
+	Err error
+}
+
+func (e *RolledBackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RolledBackError) Unwrap() error {
+	return e.Err
+}
+
+// CreateOrUpdateChartMgr installs the release for the given ChartManager
+// if it does not yet exist, or upgrades it otherwise, reporting via the
+// bool result whether this call created the release. A CreateOnly
+// ChartManager whose release is already Deployed is not upgraded; its
+// existing release is returned unchanged. ctx bounds the
+// underlying Helm action; callers typically derive it from
+// context.WithTimeout so a SIGTERM or a stuck install does not block the
+// reconcile loop indefinitely. logger is expected to already carry the
+// reconcile's chartmgr/release/reconcileID fields.
+func CreateOrUpdateChartMgr(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager, client *Client) (*Release, bool, error) {
+	actionConfig, err := client.actionConfigFor(chartmgr.Spec.Namespace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	statusAction := action.NewStatus(actionConfig)
+	existing, err := statusAction.Run(chartmgr.Spec.ReleaseName)
+	switch {
+	case err == nil:
+		if current := NewRelease(existing); CreateOnly(chartmgr) && current.Deployed() {
+			return current, false, nil
+		}
+		rls, err := client.Upgrade(ctx, logger, chartmgr)
+		return rls, false, err
+	case errors.Is(err, driver.ErrReleaseNotFound):
+		rls, err := client.Install(ctx, logger, chartmgr)
+		return rls, true, err
+	default:
+		return nil, false, fmt.Errorf("failed to look up release %s: %v", chartmgr.Spec.ReleaseName, err)
+	}
+}
+
+// DeleteChartMgr uninstalls the release backing the given ChartManager.
+func DeleteChartMgr(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager, client *Client) (*Release, error) {
+	return client.Delete(ctx, logger, chartmgr)
+}
+
+// Install creates the release for the given ChartManager.
+func (c *Client) Install(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager) (*Release, error) {
+	chrt, err := locateChart(chartmgr)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := chartutil.ReadValues([]byte(chartmgr.Spec.Values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values for release %s: %v", chartmgr.Spec.ReleaseName, err)
+	}
+
+	actionConfig, err := c.actionConfigFor(chartmgr.Spec.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	postRenderer, err := postRendererFor(chartmgr)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(actionConfig)
+	install.ReleaseName = chartmgr.Spec.ReleaseName
+	install.Namespace = chartmgr.Spec.Namespace
+	install.DisableHooks = chartmgr.Spec.DisableHooks
+	install.Atomic = chartmgr.Spec.Atomic
+	install.PostRenderer = postRenderer
+	if deadline, ok := ctx.Deadline(); ok {
+		// Atomic installs force Wait = true internally; without a
+		// Timeout the readiness wait has no budget and fails (and
+		// therefore rolls back) almost immediately.
+		install.Timeout = time.Until(deadline)
+	}
+
+	logger.Debugf("building resources from manifest")
+
+	resp, err := install.RunWithContext(ctx, chrt, values)
+	if err != nil {
+		err = fmt.Errorf("failed to install release %s: %v", chartmgr.Spec.ReleaseName, err)
+		if chartmgr.Spec.Atomic {
+			logger.Errorf("install failed atomically, release %s has been uninstalled", chartmgr.Spec.ReleaseName)
+			return nil, &RolledBackError{Err: err}
+		}
+		return nil, err
+	}
+	rls := NewRelease(resp)
+	logger.Infof("creating %d resource(s)", countManifestResources(resp.Manifest))
+	return rls, nil
+}
+
+// Upgrade updates the release for the given ChartManager in place.
+func (c *Client) Upgrade(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager) (*Release, error) {
+	chrt, err := locateChart(chartmgr)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := chartutil.ReadValues([]byte(chartmgr.Spec.Values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values for release %s: %v", chartmgr.Spec.ReleaseName, err)
+	}
+
+	actionConfig, err := c.actionConfigFor(chartmgr.Spec.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	postRenderer, err := postRendererFor(chartmgr)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade := action.NewUpgrade(actionConfig)
+	upgrade.Namespace = chartmgr.Spec.Namespace
+	upgrade.MaxHistory = chartmgr.Spec.MaxHistory
+	upgrade.CleanupOnFail = chartmgr.Spec.CleanupOnFail
+	upgrade.DisableHooks = chartmgr.Spec.DisableHooks
+	upgrade.ResetValues = chartmgr.Spec.ResetValues
+	upgrade.ReuseValues = chartmgr.Spec.ReuseValues
+	upgrade.Atomic = chartmgr.Spec.Atomic
+	upgrade.PostRenderer = postRenderer
+	if deadline, ok := ctx.Deadline(); ok {
+		upgrade.Timeout = time.Until(deadline)
+	}
+
+	logger.Debugf("building resources from manifest")
+
+	resp, err := upgrade.RunWithContext(ctx, chartmgr.Spec.ReleaseName, chrt, values)
+	if err != nil {
+		err = fmt.Errorf("failed to upgrade release %s: %v", chartmgr.Spec.ReleaseName, err)
+		if chartmgr.Spec.Atomic {
+			logger.Errorf("upgrade failed atomically, release %s has been rolled back", chartmgr.Spec.ReleaseName)
+			return nil, &RolledBackError{Err: err}
+		}
+		return nil, err
+	}
+	rls := NewRelease(resp)
+	logger.Infof("checking %d resource(s) for changes", countManifestResources(resp.Manifest))
+	return rls, nil
+}
+
+// Delete uninstalls the release for the given ChartManager.
+func (c *Client) Delete(ctx context.Context, logger log.Logger, chartmgr *crv1alpha1.ChartManager) (*Release, error) {
+	logger.Debugf("deleting release %s", chartmgr.Spec.ReleaseName)
+
+	actionConfig, err := c.actionConfigFor(chartmgr.Spec.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	uninstall := action.NewUninstall(actionConfig)
+
+	var rls *Release
+	err = runWithContext(ctx, func() error {
+		resp, err := uninstall.Run(chartmgr.Spec.ReleaseName)
+		if err != nil {
+			return err
+		}
+		rls = NewRelease(resp.Release)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete release %s: %v", chartmgr.Spec.ReleaseName, err)
+	}
+	return rls, nil
+}
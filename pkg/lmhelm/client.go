@@ -0,0 +1,79 @@
+package lmhelm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/config"
+	"github.com/logicmonitor/k8s-chart-manager-controller/pkg/log"
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/client-go/rest"
+)
+
+// defaultStorageDriver is used when config.Config does not set
+// StorageDriver.
+const defaultStorageDriver = "secret"
+
+// Client wraps the Helm v3 action package with the bits of
+// configuration the Chart Manager controller needs to drive it: a
+// RESTClientGetter built from the controller's own rest.Config rather
+// than a kubeconfig file, since Helm v3 talks to the API server directly
+// and no longer depends on an in-cluster Tiller.
+type Client struct {
+	Logger log.Logger
+
+	restConfig    *rest.Config
+	storageDriver string
+}
+
+// Init records the configuration action.Configuration instances for
+// individual namespaces will be built from, selecting the storage driver
+// configured on chartmgrconfig (defaulting to Kubernetes Secrets,
+// matching Helm v3's own default).
+func (c *Client) Init(chartmgrconfig *config.Config, restconfig *rest.Config, logger log.Logger) error {
+	c.Logger = logger
+	c.restConfig = restconfig
+
+	c.storageDriver = chartmgrconfig.StorageDriver
+	if c.storageDriver == "" {
+		c.storageDriver = defaultStorageDriver
+	}
+	return nil
+}
+
+// actionConfigFor builds a Helm v3 action.Configuration scoped to
+// namespace. Helm v3 has no cluster-wide Tiller to share, so every
+// namespace gets its own configuration.
+func (c *Client) actionConfigFor(namespace string) (*action.Configuration, error) {
+	getter := &inClusterRESTClientGetter{restConfig: c.restConfig, namespace: namespace}
+
+	actionConfig := new(action.Configuration)
+	err := actionConfig.Init(getter, namespace, c.storageDriver, func(format string, v ...interface{}) {
+		c.Logger.Debugf(format, v...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Helm action configuration: %v", err)
+	}
+	return actionConfig, nil
+}
+
+// runWithContext runs op in its own goroutine and waits for it to
+// finish, unless ctx is cancelled or its deadline expires first. It is
+// only a fallback for actions such as action.Uninstall that have no
+// context-aware Run variant of their own; Install and Upgrade use their
+// native RunWithContext instead, which actually unwinds the in-flight
+// operation (e.g. aborting an atomic rollback wait) rather than merely
+// abandoning it in the background.
+func runWithContext(ctx context.Context, op func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,19 @@
+package lmhelm
+
+import "strings"
+
+// countManifestResources counts the documents in a rendered Helm
+// manifest by counting their top-level "kind:" fields, so phase logging
+// can report how many resources an install or upgrade touches without
+// parsing them into full Kubernetes objects. Only unindented "kind:"
+// lines count, since a resource's own fields (e.g. a RoleBinding's
+// roleRef.kind) would otherwise be mistaken for separate documents.
+func countManifestResources(manifest string) int {
+	count := 0
+	for _, line := range strings.Split(manifest, "\n") {
+		if strings.HasPrefix(line, "kind:") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,61 @@
+package lmhelm
+
+import "testing"
+
+func TestCountManifestResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     int
+	}{
+		{name: "empty manifest", manifest: "", want: 0},
+		{
+			name: "single document",
+			manifest: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`,
+			want: 1,
+		},
+		{
+			name: "multiple documents separated by ---",
+			manifest: `apiVersion: v1
+kind: ConfigMap
+---
+apiVersion: apps/v1
+kind: Deployment
+---
+apiVersion: v1
+kind: Service
+`,
+			want: 3,
+		},
+		{
+			name:     "indented kind field is not counted",
+			manifest: "spec:\n  template:\n    kind: Pod\n",
+			want:     0,
+		},
+		{
+			name: "nested roleRef.kind does not inflate a RoleBinding's count",
+			manifest: `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: foo
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: foo
+`,
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countManifestResources(tt.manifest); got != tt.want {
+				t.Errorf("countManifestResources() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package lmhelm
+
+import (
+	"fmt"
+
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"helm.sh/helm/v3/pkg/postrender"
+)
+
+// postRendererFor builds the Helm v3 postrender.PostRenderer described by
+// chartmgr.Spec.PostRenderer, or nil if none is configured.
+func postRendererFor(chartmgr *crv1alpha1.ChartManager) (postrender.PostRenderer, error) {
+	spec := chartmgr.Spec.PostRenderer
+	if spec == nil || spec.Command == "" {
+		return nil, nil
+	}
+
+	renderer, err := postrender.NewExec(spec.Command, spec.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure post-renderer %s for release %s: %v",
+			spec.Command, chartmgr.Spec.ReleaseName, err)
+	}
+	return renderer, nil
+}
@@ -0,0 +1,43 @@
+package lmhelm
+
+import (
+	crv1alpha1 "github.com/logicmonitor/k8s-chart-manager-controller/pkg/apis/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Release wraps a Helm v3 release so that the controller and its
+// callers don't need to reach into the Helm SDK types directly.
+type Release struct {
+	release *release.Release
+}
+
+// NewRelease wraps the given Helm v3 release.
+func NewRelease(rls *release.Release) *Release {
+	return &Release{release: rls}
+}
+
+// Name returns the release name, or the empty string if the release is
+// nil, e.g. because an install failed before Helm created it.
+func (r *Release) Name() string {
+	if r == nil || r.release == nil {
+		return ""
+	}
+	return r.release.Name
+}
+
+// Status returns the ChartMgrState corresponding to the release's
+// current Helm status.
+func (r *Release) Status() crv1alpha1.ChartMgrState {
+	if r == nil || r.release == nil || r.release.Info == nil {
+		return crv1alpha1.StateUnknown
+	}
+	if r.release.Info.Status == release.StatusDeployed {
+		return crv1alpha1.StateDeployed
+	}
+	return crv1alpha1.StateFailed
+}
+
+// Deployed reports whether the release has reached the Deployed state.
+func (r *Release) Deployed() bool {
+	return r.Status() == crv1alpha1.StateDeployed
+}
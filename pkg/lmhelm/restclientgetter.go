@@ -0,0 +1,51 @@
+package lmhelm
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// inClusterRESTClientGetter adapts a rest.Config the controller already
+// has (built from rest.InClusterConfig or a kubeconfig) to Helm v3's
+// genericclioptions.RESTClientGetter, which action.Configuration.Init
+// requires to reach the API server and discovery.
+type inClusterRESTClientGetter struct {
+	restConfig *rest.Config
+	namespace  string
+}
+
+var _ genericclioptions.RESTClientGetter = (*inClusterRESTClientGetter)(nil)
+
+func (g *inClusterRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return g.restConfig, nil
+}
+
+func (g *inClusterRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(g.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(discoveryClient), nil
+}
+
+func (g *inClusterRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	discoveryClient, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient), nil
+}
+
+func (g *inClusterRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	overrides := &clientcmd.ConfigOverrides{Context: api.Context{Namespace: g.namespace}}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		overrides,
+	)
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// klogLogger adapts klog to Logger. Since klog has no structured
+// key/value API of its own, With renders its keyvals into a prefix
+// applied to every subsequent format string.
+type klogLogger struct {
+	prefix string
+}
+
+// NewKlog returns a Logger backed by klog.
+func NewKlog() Logger {
+	return &klogLogger{}
+}
+
+func (l *klogLogger) Debugf(format string, args ...interface{}) {
+	klog.V(1).Infof(l.prefix+format, args...)
+}
+
+func (l *klogLogger) Infof(format string, args ...interface{}) {
+	klog.Infof(l.prefix+format, args...)
+}
+
+func (l *klogLogger) Warnf(format string, args ...interface{}) {
+	klog.Warningf(l.prefix+format, args...)
+}
+
+func (l *klogLogger) Errorf(format string, args ...interface{}) {
+	klog.Errorf(l.prefix+format, args...)
+}
+
+func (l *klogLogger) With(keyvals ...interface{}) Logger {
+	return &klogLogger{prefix: l.prefix + prefixFrom(keyvals)}
+}
+
+func prefixFrom(keyvals []interface{}) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&b, "%v=%v ", keyvals[i], keyvals[i+1])
+	}
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package log
+
+import "testing"
+
+func TestKlogLoggerWithPrefix(t *testing.T) {
+	logger := NewKlog().With("release", "my-release").With("tier", 1)
+
+	l, ok := logger.(*klogLogger)
+	if !ok {
+		t.Fatalf("With() returned %T, want *klogLogger", logger)
+	}
+
+	want := "release=my-release tier=1 "
+	if l.prefix != want {
+		t.Errorf("prefix = %q, want %q", l.prefix, want)
+	}
+}
@@ -0,0 +1,17 @@
+// Package log defines the structured logging interface used throughout
+// the Chart Manager controller, decoupling it from any single logging
+// library.
+package log
+
+// Logger is implemented by the logging backends the controller can be
+// configured with (logrus by default, klog, zap).
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+
+	// With returns a Logger that carries the given alternating
+	// key/value pairs on every subsequent log line.
+	With(keyvals ...interface{}) Logger
+}
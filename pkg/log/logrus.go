@@ -0,0 +1,35 @@
+package log
+
+import "github.com/sirupsen/logrus"
+
+// logrusLogger adapts a *logrus.Entry to Logger. It is the default
+// backend used when the controller is not configured with another one.
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogrus returns a Logger backed by logrus's standard logger.
+func NewLogrus() Logger {
+	return &logrusLogger{entry: logrus.NewEntry(logrus.StandardLogger())}
+}
+
+func (l *logrusLogger) Debugf(format string, args ...interface{}) { l.entry.Debugf(format, args...) }
+func (l *logrusLogger) Infof(format string, args ...interface{})  { l.entry.Infof(format, args...) }
+func (l *logrusLogger) Warnf(format string, args ...interface{})  { l.entry.Warnf(format, args...) }
+func (l *logrusLogger) Errorf(format string, args ...interface{}) { l.entry.Errorf(format, args...) }
+
+func (l *logrusLogger) With(keyvals ...interface{}) Logger {
+	return &logrusLogger{entry: l.entry.WithFields(fieldsFrom(keyvals))}
+}
+
+func fieldsFrom(keyvals []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, len(keyvals)/2)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		fields[key] = keyvals[i+1]
+	}
+	return fields
+}
@@ -0,0 +1,24 @@
+package log
+
+import "testing"
+
+func TestLogrusLoggerWithFields(t *testing.T) {
+	logger := NewLogrus().With("release", "my-release", "tier", 1)
+
+	l, ok := logger.(*logrusLogger)
+	if !ok {
+		t.Fatalf("With() returned %T, want *logrusLogger", logger)
+	}
+
+	got := l.entry.Data
+	if got["release"] != "my-release" || got["tier"] != 1 {
+		t.Errorf("entry.Data = %v, want release=my-release tier=1", got)
+	}
+}
+
+func TestFieldsFromOddKeyvals(t *testing.T) {
+	fields := fieldsFrom([]interface{}{"key1", "value1", "dangling"})
+	if len(fields) != 1 || fields["key1"] != "value1" {
+		t.Errorf("fieldsFrom() = %v, want only key1=value1", fields)
+	}
+}
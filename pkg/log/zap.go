@@ -0,0 +1,22 @@
+package log
+
+import "go.uber.org/zap"
+
+// zapLogger adapts a *zap.SugaredLogger to Logger.
+type zapLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// NewZap returns a Logger backed by the given zap logger.
+func NewZap(logger *zap.Logger) Logger {
+	return &zapLogger{sugar: logger.Sugar()}
+}
+
+func (l *zapLogger) Debugf(format string, args ...interface{}) { l.sugar.Debugf(format, args...) }
+func (l *zapLogger) Infof(format string, args ...interface{})  { l.sugar.Infof(format, args...) }
+func (l *zapLogger) Warnf(format string, args ...interface{})  { l.sugar.Warnf(format, args...) }
+func (l *zapLogger) Errorf(format string, args ...interface{}) { l.sugar.Errorf(format, args...) }
+
+func (l *zapLogger) With(keyvals ...interface{}) Logger {
+	return &zapLogger{sugar: l.sugar.With(keyvals...)}
+}
@@ -0,0 +1,25 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerWithFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewZap(zap.New(core)).With("release", "my-release")
+
+	logger.Infof("reconciled")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["release"] != "my-release" {
+		t.Errorf("fields = %v, want release=my-release", fields)
+	}
+}